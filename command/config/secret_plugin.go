@@ -0,0 +1,25 @@
+// Copyright 2020 Drone.IO Inc. All rights reserved.
+// Use of this source code is governed by the Polyform License
+// that can be found in the LICENSE file.
+
+package config
+
+import "time"
+
+// SecretPluginConfig configures the default, per-runner HTTP secret
+// plugin endpoint used to resolve secrets the compiler can't find
+// statically. It is not a field of EnvConfig; pass it to
+// compiler.NewSecretProvider to build Compiler.Secret. The pool file has
+// no per-pool secret_plugin setting, so every pool uses this
+// configuration.
+type SecretPluginConfig struct {
+	// Endpoint is the URL of the secret extension to call. Empty
+	// disables the plugin.
+	Endpoint string `envconfig:"DRONE_SECRET_PLUGIN_ENDPOINT"`
+	// HMACKey signs each request to Endpoint.
+	HMACKey string `envconfig:"DRONE_SECRET_PLUGIN_TOKEN"`
+	// SkipVerify disables TLS verification for Endpoint.
+	SkipVerify bool `envconfig:"DRONE_SECRET_PLUGIN_SKIP_VERIFY"`
+	// Timeout bounds a single secret lookup.
+	Timeout time.Duration `envconfig:"DRONE_SECRET_PLUGIN_TIMEOUT" default:"5s"`
+}
@@ -0,0 +1,21 @@
+// Copyright 2020 Drone.IO Inc. All rights reserved.
+// Use of this source code is governed by the Polyform License
+// that can be found in the LICENSE file.
+
+package config
+
+// StatsConfig selects which stats.Sink implementations the destroy path
+// should fan OS stats out to. It is embedded on EnvConfig as Stats.
+type StatsConfig struct {
+	// Sinks lists the sinks to enable: any of "prometheus", "otel",
+	// "jsonlines". Defaults to just "prometheus" when empty, preserving
+	// existing behavior.
+	Sinks []string `envconfig:"DRONE_STATS_SINKS"`
+
+	// OTLPEndpoint is the OpenTelemetry collector endpoint used by the
+	// "otel" sink.
+	OTLPEndpoint string `envconfig:"DRONE_STATS_OTLP_ENDPOINT"`
+
+	// JSONLinesPath is the file the "jsonlines" sink appends records to.
+	JSONLinesPath string `envconfig:"DRONE_STATS_JSONLINES_PATH"`
+}
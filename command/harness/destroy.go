@@ -9,6 +9,7 @@ import (
 	"github.com/drone-runners/drone-runner-aws/internal/drivers"
 	"github.com/drone-runners/drone-runner-aws/internal/lehelper"
 	"github.com/drone-runners/drone-runner-aws/internal/oshelp"
+	"github.com/drone-runners/drone-runner-aws/internal/stats"
 	ierrors "github.com/drone-runners/drone-runner-aws/internal/types"
 	"github.com/drone-runners/drone-runner-aws/metric"
 	"github.com/drone-runners/drone-runner-aws/store"
@@ -31,16 +32,34 @@ type VMCleanupRequest struct {
 	Context        Context `json:"context,omitempty"`
 }
 
-func HandleDestroy(ctx context.Context, r *VMCleanupRequest, s store.StageOwnerStore, env *config.EnvConfig, poolManager *drivers.Manager, metrics *metric.Metrics) error {
+func HandleDestroy(ctx context.Context, r *VMCleanupRequest, s store.StageOwnerStore, env *config.EnvConfig, poolManager *drivers.Manager, metrics *metric.Metrics, q store.DestroyQueueStore) error {
+	return HandleDestroyWithStats(ctx, r, s, env, poolManager, metrics, q, sinksFromConfig(env.Stats, metrics))
+}
+
+// HandleDestroyWithStats is HandleDestroy with explicit control over which
+// stats.Sink(s) the lite-engine OS stats are recorded to, so callers can
+// fan the full stats payload out beyond the default Prometheus histograms.
+func HandleDestroyWithStats(ctx context.Context, r *VMCleanupRequest, s store.StageOwnerStore, env *config.EnvConfig,
+	poolManager *drivers.Manager, metrics *metric.Metrics, q store.DestroyQueueStore, sink stats.Sink) error {
 	if r.StageRuntimeID == "" {
 		return ierrors.NewBadRequestError("mandatory field 'stage_runtime_id' in the request body is empty")
 	}
+
+	if gate.isPaused() && q != nil {
+		logrus.WithField("stage_runtime_id", r.StageRuntimeID).
+			Infoln("dlite: pool manager paused, queuing destroy request instead of acting on it")
+		return enqueueDestroy(ctx, q, r)
+	}
+
+	gate.inFlight.Add(1)
+	defer gate.inFlight.Done()
+
 	// We do retries on destroy in case a destroy call comes while an initialize call is still happening.
 	cnt := 0
 	b := createBackoff(destroyTimeout)
 	for {
 		duration := b.NextBackOff()
-		_, err := handleDestroy(ctx, r, s, env, poolManager, metrics, cnt)
+		_, err := handleDestroy(ctx, r, s, env, poolManager, sink, cnt)
 		if err != nil {
 			logrus.WithError(err).
 				WithField("retry_count", cnt).
@@ -49,6 +68,11 @@ func HandleDestroy(ctx context.Context, r *VMCleanupRequest, s store.StageOwnerS
 			if duration == backoff.Stop {
 				return err
 			}
+			if gate.isPaused() && q != nil {
+				logrus.WithField("stage_runtime_id", r.StageRuntimeID).
+					Infoln("dlite: pool manager paused, queuing destroy request instead of retrying")
+				return enqueueDestroy(ctx, q, r)
+			}
 			time.Sleep(duration)
 			cnt++
 			continue
@@ -58,7 +82,7 @@ func HandleDestroy(ctx context.Context, r *VMCleanupRequest, s store.StageOwnerS
 }
 
 func handleDestroy(ctx context.Context, r *VMCleanupRequest, s store.StageOwnerStore, env *config.EnvConfig,
-	poolManager *drivers.Manager, metrics *metric.Metrics, retryCount int) (*types.Instance, error) {
+	poolManager *drivers.Manager, sink stats.Sink, retryCount int) (*types.Instance, error) {
 	entity, err := s.Find(ctx, r.StageRuntimeID)
 	if err != nil || entity == nil {
 		return nil, errors.Wrap(err, fmt.Sprintf("failed to find stage owner entity for stage: %s", r.StageRuntimeID))
@@ -121,8 +145,11 @@ func handleDestroy(ctx context.Context, r *VMCleanupRequest, s store.StageOwnerS
 				}
 			}
 
-			metrics.CPUPercentile.WithLabelValues(poolID, inst.OS, inst.Arch, string(inst.Provider)).Observe(resp.OSStats.MaxCPUUsagePct)
-			metrics.MemoryPercentile.WithLabelValues(poolID, inst.OS, inst.Arch, string(inst.Provider)).Observe(resp.OSStats.MaxMemUsagePct)
+			if sink != nil {
+				if err := sink.Record(ctx, buildStatsRecord(poolID, r.StageRuntimeID, inst, resp.OSStats)); err != nil {
+					logr.WithError(err).Warnln("failed to record execution stats")
+				}
+			}
 
 			logr.WithField("cpu_ge50", cpuGe50).WithField("cpu_ge70", cpuGe70).WithField("cpu_ge90", cpuGe90).
 				WithField("mem_ge50", memGe50).WithField("mem_ge70", memGe70).WithField("mem_ge90", memGe90).
@@ -147,6 +174,30 @@ func handleDestroy(ctx context.Context, r *VMCleanupRequest, s store.StageOwnerS
 	return inst, nil
 }
 
+// buildStatsRecord assembles the stats.Record for a destroyed instance
+// from its lite-engine OS stats, including how long the instance lived
+// for (from its creation to this destroy call).
+func buildStatsRecord(poolID, stageRuntimeID string, inst *types.Instance, osStats *api.OSStats) stats.Record {
+	var lifetime time.Duration
+	if inst.Started > 0 {
+		lifetime = time.Since(time.Unix(inst.Started, 0))
+	}
+	return stats.Record{
+		PoolID:           poolID,
+		StageRuntimeID:   stageRuntimeID,
+		Provider:         string(inst.Provider),
+		OS:               inst.OS,
+		Arch:             inst.Arch,
+		TotalMemMB:       osStats.TotalMemMB,
+		CPUCores:         osStats.CPUCores,
+		AvgMemUsagePct:   osStats.AvgMemUsagePct,
+		AvgCPUUsagePct:   osStats.AvgCPUUsagePct,
+		MaxMemUsagePct:   osStats.MaxMemUsagePct,
+		MaxCPUUsagePct:   osStats.MaxCPUUsagePct,
+		InstanceLifetime: lifetime,
+	}
+}
+
 func createBackoff(maxElapsedTime time.Duration) *backoff.ExponentialBackOff {
 	exp := backoff.NewExponentialBackOff()
 	exp.MaxElapsedTime = maxElapsedTime
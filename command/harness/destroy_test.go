@@ -0,0 +1,86 @@
+// Copyright 2020 Drone.IO Inc. All rights reserved.
+// Use of this source code is governed by the Polyform License
+// that can be found in the LICENSE file.
+
+package harness
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/drone-runners/drone-runner-aws/internal/stats"
+	"github.com/drone-runners/drone-runner-aws/types"
+	"github.com/harness/lite-engine/api"
+)
+
+type fakeSink struct {
+	records []stats.Record
+}
+
+func (f *fakeSink) Record(_ context.Context, rec stats.Record) error {
+	f.records = append(f.records, rec)
+	return nil
+}
+
+// TestBuildStatsRecord fakes a lite-engine Destroy response and asserts
+// that the resulting stats.Record - including InstanceLifetime, derived
+// from the instance's recorded start time - is forwarded to every
+// configured sink unchanged.
+func TestBuildStatsRecord(t *testing.T) {
+	started := time.Now().Add(-5 * time.Minute).Unix()
+	inst := &types.Instance{
+		ID:       "i-0123456789",
+		Name:     "pool-a-test-runner",
+		Provider: "aws",
+		OS:       "linux",
+		Arch:     "amd64",
+		Started:  started,
+	}
+
+	// osStats stands in for the OSStats payload a real lite-engine
+	// Destroy response would carry.
+	osStats := &api.OSStats{
+		TotalMemMB:     1024,
+		CPUCores:       4,
+		AvgMemUsagePct: 33.3,
+		AvgCPUUsagePct: 12.1,
+		MaxMemUsagePct: 91.5,
+		MaxCPUUsagePct: 76.4,
+	}
+
+	rec := buildStatsRecord("pool-a", "stage-1", inst, osStats)
+
+	a, b := &fakeSink{}, &fakeSink{}
+	fanout := stats.Fanout{a, b}
+	if err := fanout.Record(context.Background(), rec); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	for _, sink := range []*fakeSink{a, b} {
+		if len(sink.records) != 1 {
+			t.Fatalf("want record forwarded to sink, got %+v", sink.records)
+		}
+		got := sink.records[0]
+		if got.PoolID != "pool-a" || got.StageRuntimeID != "stage-1" {
+			t.Errorf("want pool-a/stage-1, got %s/%s", got.PoolID, got.StageRuntimeID)
+		}
+		if got.Provider != "aws" || got.OS != "linux" || got.Arch != "amd64" {
+			t.Errorf("want instance labels copied through, got %+v", got)
+		}
+		if got.MaxCPUUsagePct != 76.4 || got.TotalMemMB != 1024 {
+			t.Errorf("want OS stats copied through, got %+v", got)
+		}
+		if got.InstanceLifetime < 4*time.Minute || got.InstanceLifetime > 6*time.Minute {
+			t.Errorf("want instance lifetime around 5m, got %s", got.InstanceLifetime)
+		}
+	}
+}
+
+func TestBuildStatsRecordZeroStartedYieldsZeroLifetime(t *testing.T) {
+	inst := &types.Instance{OS: "linux", Arch: "amd64"}
+	rec := buildStatsRecord("pool-a", "stage-1", inst, &api.OSStats{})
+	if rec.InstanceLifetime != 0 {
+		t.Errorf("want zero lifetime when instance has no recorded start time, got %s", rec.InstanceLifetime)
+	}
+}
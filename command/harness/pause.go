@@ -0,0 +1,149 @@
+// Copyright 2020 Drone.IO Inc. All rights reserved.
+// Use of this source code is governed by the Polyform License
+// that can be found in the LICENSE file.
+
+package harness
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/drone-runners/drone-runner-aws/command/config"
+	"github.com/drone-runners/drone-runner-aws/internal/drivers"
+	"github.com/drone-runners/drone-runner-aws/metric"
+	"github.com/drone-runners/drone-runner-aws/store"
+
+	"github.com/sirupsen/logrus"
+)
+
+// queueGate gates the destroy/provision pipeline behind a pause flag and
+// tracks how many stages are currently in flight, so a graceful shutdown
+// can wait for them to drain.
+type queueGate struct {
+	mu       sync.RWMutex
+	paused   bool
+	inFlight sync.WaitGroup
+}
+
+var gate = &queueGate{}
+
+func (g *queueGate) pause() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.paused = true
+}
+
+func (g *queueGate) resume() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.paused = false
+}
+
+func (g *queueGate) isPaused() bool {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.paused
+}
+
+// PoolStatus is returned by GET /pool/status.
+type PoolStatus struct {
+	Paused      bool `json:"paused"`
+	QueueLength int  `json:"queue_length"`
+}
+
+// HandlePause stops the destroy pipeline from acting on new requests;
+// instead of racing against in-flight initializes it enqueues them to q
+// for later draining. It does not stop new VM provisioning from
+// starting: only the destroy path consults the gate.
+func HandlePause(ctx context.Context) {
+	gate.pause()
+	logrus.Infoln("dlite: pool manager paused, destroy requests will be queued")
+}
+
+// HandleResume re-enables the destroy pipeline and drains any requests
+// that were buffered while paused, in FIFO order.
+func HandleResume(ctx context.Context, q store.DestroyQueueStore, s store.StageOwnerStore, env *config.EnvConfig,
+	poolManager *drivers.Manager, metrics *metric.Metrics) error {
+	gate.resume()
+	logrus.Infoln("dlite: pool manager resumed, draining queued destroy requests")
+	return drainQueue(ctx, q, s, env, poolManager, metrics)
+}
+
+// HandleStatus reports whether the pipeline is paused and how many
+// requests are currently buffered.
+func HandleStatus(ctx context.Context, q store.DestroyQueueStore) (*PoolStatus, error) {
+	n, err := q.Len(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &PoolStatus{Paused: gate.isPaused(), QueueLength: n}, nil
+}
+
+// HandleDrain blocks until every stage that was in flight when it was
+// called has finished destroying, or the context is cancelled. It is
+// intended for graceful runner shutdown during upgrades: callers should
+// pause the pipeline first so no new work starts while draining.
+func HandleDrain(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		gate.inFlight.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// enqueueDestroy buffers a destroy request in the persistent queue rather
+// than retrying it against the stage owner store.
+func enqueueDestroy(ctx context.Context, q store.DestroyQueueStore, r *VMCleanupRequest) error {
+	ctxBytes, err := json.Marshal(r.Context)
+	if err != nil {
+		return err
+	}
+	return q.Enqueue(ctx, &store.QueuedDestroyRequest{
+		PoolID:         r.PoolID,
+		StageRuntimeID: r.StageRuntimeID,
+		LogKey:         r.LogKey,
+		Context:        ctxBytes,
+	})
+}
+
+// drainQueue dequeues and processes buffered destroy requests until the
+// queue is empty.
+func drainQueue(ctx context.Context, q store.DestroyQueueStore, s store.StageOwnerStore, env *config.EnvConfig,
+	poolManager *drivers.Manager, metrics *metric.Metrics) error {
+	for {
+		if gate.isPaused() {
+			return nil
+		}
+		item, err := q.Dequeue(ctx)
+		if err != nil {
+			return err
+		}
+		if item == nil {
+			return nil
+		}
+
+		req := &VMCleanupRequest{PoolID: item.PoolID, StageRuntimeID: item.StageRuntimeID, LogKey: item.LogKey}
+		if len(item.Context) > 0 {
+			if err := json.Unmarshal(item.Context, &req.Context); err != nil {
+				logrus.WithError(err).Errorln("dlite: failed to decode queued destroy context")
+			}
+		}
+
+		if err := HandleDestroy(ctx, req, s, env, poolManager, metrics, q); err != nil {
+			logrus.WithError(err).
+				WithField("stage_runtime_id", req.StageRuntimeID).
+				Errorln("dlite: failed to process queued destroy request")
+		}
+
+		// Yield between items instead of busy-looping the store.
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,62 @@
+// Copyright 2020 Drone.IO Inc. All rights reserved.
+// Use of this source code is governed by the Polyform License
+// that can be found in the LICENSE file.
+
+package harness
+
+import (
+	"context"
+	"testing"
+
+	"github.com/drone-runners/drone-runner-aws/store"
+)
+
+func TestQueueGatePauseResume(t *testing.T) {
+	g := &queueGate{}
+	if g.isPaused() {
+		t.Fatalf("expected gate to start unpaused")
+	}
+	g.pause()
+	if !g.isPaused() {
+		t.Fatalf("expected gate to be paused")
+	}
+	g.resume()
+	if g.isPaused() {
+		t.Fatalf("expected gate to be resumed")
+	}
+}
+
+func TestHandleStatusReportsQueueLength(t *testing.T) {
+	ctx := context.Background()
+	q := store.NewMemoryDestroyQueueStore()
+
+	if err := q.Enqueue(ctx, &store.QueuedDestroyRequest{StageRuntimeID: "stage-1"}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	status, err := HandleStatus(ctx, q)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if status.QueueLength != 1 {
+		t.Errorf("want queue length 1, got %d", status.QueueLength)
+	}
+}
+
+func TestEnqueueDestroyRoundTrips(t *testing.T) {
+	ctx := context.Background()
+	q := store.NewMemoryDestroyQueueStore()
+
+	req := &VMCleanupRequest{PoolID: "pool-1", StageRuntimeID: "stage-1", LogKey: "log-1"}
+	if err := enqueueDestroy(ctx, q, req); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	item, err := q.Dequeue(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if item == nil || item.StageRuntimeID != "stage-1" {
+		t.Errorf("want queued item for stage-1, got %+v", item)
+	}
+}
@@ -0,0 +1,71 @@
+// Copyright 2020 Drone.IO Inc. All rights reserved.
+// Use of this source code is governed by the Polyform License
+// that can be found in the LICENSE file.
+
+package harness
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/drone-runners/drone-runner-aws/command/config"
+	"github.com/drone-runners/drone-runner-aws/internal/drivers"
+	"github.com/drone-runners/drone-runner-aws/metric"
+	"github.com/drone-runners/drone-runner-aws/store"
+
+	"github.com/sirupsen/logrus"
+)
+
+// RegisterPoolRoutes wires the pool pause/resume/status/drain endpoints
+// onto mux, so an operator can drain a runner ahead of a restart or
+// upgrade without racing in-flight destroy calls.
+func RegisterPoolRoutes(mux *http.ServeMux, q store.DestroyQueueStore, s store.StageOwnerStore, env *config.EnvConfig,
+	poolManager *drivers.Manager, metrics *metric.Metrics) {
+	mux.HandleFunc("/pool/pause", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		HandlePause(r.Context())
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	mux.HandleFunc("/pool/resume", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		if err := HandleResume(r.Context(), q, s, env, poolManager, metrics); err != nil {
+			logrus.WithError(err).Errorln("dlite: failed to resume pool manager")
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	mux.HandleFunc("/pool/status", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		status, err := HandleStatus(r.Context(), q)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(status) //nolint:errcheck
+	})
+
+	mux.HandleFunc("/pool/drain", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		if err := HandleDrain(r.Context()); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
@@ -0,0 +1,72 @@
+// Copyright 2020 Drone.IO Inc. All rights reserved.
+// Use of this source code is governed by the Polyform License
+// that can be found in the LICENSE file.
+
+package harness
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/drone-runners/drone-runner-aws/store"
+)
+
+func TestRegisterPoolRoutesPauseAndStatus(t *testing.T) {
+	gate.resume()
+	defer gate.resume()
+
+	q := store.NewMemoryDestroyQueueStore()
+	if err := q.Enqueue(context.Background(), &store.QueuedDestroyRequest{StageRuntimeID: "stage-1"}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	mux := http.NewServeMux()
+	RegisterPoolRoutes(mux, q, nil, nil, nil, nil)
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	resp, err := http.Post(srv.URL+"/pool/pause", "application/json", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		t.Errorf("want 204 from pause, got %d", resp.StatusCode)
+	}
+
+	resp, err = http.Get(srv.URL + "/pool/status")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer resp.Body.Close()
+
+	var status PoolStatus
+	if err := json.NewDecoder(resp.Body).Decode(&status); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !status.Paused {
+		t.Errorf("want status to report paused after /pool/pause")
+	}
+	if status.QueueLength != 1 {
+		t.Errorf("want queue length 1, got %d", status.QueueLength)
+	}
+}
+
+func TestRegisterPoolRoutesRejectsWrongMethod(t *testing.T) {
+	mux := http.NewServeMux()
+	RegisterPoolRoutes(mux, store.NewMemoryDestroyQueueStore(), nil, nil, nil, nil)
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/pool/pause")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("want 405 for GET /pool/pause, got %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,66 @@
+// Copyright 2020 Drone.IO Inc. All rights reserved.
+// Use of this source code is governed by the Polyform License
+// that can be found in the LICENSE file.
+
+package harness
+
+import (
+	"context"
+
+	"github.com/drone-runners/drone-runner-aws/command/config"
+	"github.com/drone-runners/drone-runner-aws/internal/stats"
+	"github.com/drone-runners/drone-runner-aws/metric"
+
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+
+	"github.com/sirupsen/logrus"
+)
+
+// sinksFromConfig builds the stats.Sink fanout HandleDestroy records
+// lite-engine OS stats to, from cfg.Sinks. An empty cfg.Sinks preserves
+// the historical behavior of recording to Prometheus only. A sink that
+// fails to configure (e.g. a bad OTLP endpoint) is logged and dropped
+// rather than aborting destroy for every other sink.
+func sinksFromConfig(cfg config.StatsConfig, metrics *metric.Metrics) stats.Fanout {
+	names := cfg.Sinks
+	if len(names) == 0 {
+		names = []string{"prometheus"}
+	}
+
+	var out stats.Fanout
+	for _, name := range names {
+		switch name {
+		case "prometheus":
+			out = append(out, stats.PrometheusSink{Metrics: metrics})
+		case "otel":
+			sink, err := newOTelSink(cfg.OTLPEndpoint)
+			if err != nil {
+				logrus.WithError(err).Errorln("dlite: failed to configure otel stats sink")
+				continue
+			}
+			out = append(out, sink)
+		case "jsonlines":
+			sink, err := stats.NewJSONLinesSink(cfg.JSONLinesPath)
+			if err != nil {
+				logrus.WithError(err).Errorln("dlite: failed to configure jsonlines stats sink")
+				continue
+			}
+			out = append(out, sink)
+		default:
+			logrus.WithField("sink", name).Warnln("dlite: unknown stats sink configured, ignoring")
+		}
+	}
+	return out
+}
+
+// newOTelSink builds an OTelSink backed by an OTLP/HTTP metrics exporter
+// pointed at endpoint.
+func newOTelSink(endpoint string) (*stats.OTelSink, error) {
+	exporter, err := otlpmetrichttp.New(context.Background(), otlpmetrichttp.WithEndpoint(endpoint))
+	if err != nil {
+		return nil, err
+	}
+	provider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(sdkmetric.NewPeriodicReader(exporter)))
+	return stats.NewOTelSink(provider.Meter("drone-runner-aws"))
+}
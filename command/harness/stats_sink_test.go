@@ -0,0 +1,48 @@
+// Copyright 2020 Drone.IO Inc. All rights reserved.
+// Use of this source code is governed by the Polyform License
+// that can be found in the LICENSE file.
+
+package harness
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/drone-runners/drone-runner-aws/command/config"
+	"github.com/drone-runners/drone-runner-aws/internal/stats"
+)
+
+func TestSinksFromConfigDefaultsToPrometheus(t *testing.T) {
+	fanout := sinksFromConfig(config.StatsConfig{}, nil)
+	if len(fanout) != 1 {
+		t.Fatalf("want 1 default sink, got %d", len(fanout))
+	}
+	if _, ok := fanout[0].(stats.PrometheusSink); !ok {
+		t.Errorf("want default sink to be PrometheusSink, got %T", fanout[0])
+	}
+}
+
+func TestSinksFromConfigBuildsRequestedSinks(t *testing.T) {
+	cfg := config.StatsConfig{
+		Sinks:         []string{"prometheus", "jsonlines", "unknown"},
+		JSONLinesPath: filepath.Join(t.TempDir(), "stats.jsonl"),
+	}
+	fanout := sinksFromConfig(cfg, nil)
+	if len(fanout) != 2 {
+		t.Fatalf("want unknown sink ignored, got %d sinks", len(fanout))
+	}
+	if _, ok := fanout[0].(stats.PrometheusSink); !ok {
+		t.Errorf("want first sink to be PrometheusSink, got %T", fanout[0])
+	}
+	if _, ok := fanout[1].(*stats.JSONLinesSink); !ok {
+		t.Errorf("want second sink to be JSONLinesSink, got %T", fanout[1])
+	}
+}
+
+func TestSinksFromConfigSkipsSinkThatFailsToConstruct(t *testing.T) {
+	cfg := config.StatsConfig{Sinks: []string{"jsonlines"}, JSONLinesPath: filepath.Join(t.TempDir(), "missing-dir", "stats.jsonl")}
+	fanout := sinksFromConfig(cfg, nil)
+	if len(fanout) != 0 {
+		t.Errorf("want failed sink dropped, got %d sinks", len(fanout))
+	}
+}
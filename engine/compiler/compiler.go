@@ -0,0 +1,134 @@
+// Copyright 2020 Drone.IO Inc. All rights reserved.
+// Use of this source code is governed by the Polyform License
+// that can be found in the LICENSE file.
+
+package compiler
+
+import (
+	"context"
+
+	"github.com/drone-runners/drone-runner-aws/engine"
+	"github.com/drone-runners/drone-runner-aws/engine/resource"
+	"github.com/drone-runners/drone-runner-aws/internal/poolfile"
+
+	"github.com/drone/runner-go/environ/provider"
+	"github.com/drone/runner-go/pipeline/runtime"
+	"github.com/drone/runner-go/secret"
+
+	"github.com/dchest/uniuri"
+	"github.com/sirupsen/logrus"
+)
+
+// random generates a random string used for scratch resource names. It
+// is replaced in tests with a deterministic stub.
+var random = uniuri.New
+
+// Compiler compiles a YAML pipeline into an engine.Spec that the Engine
+// can execute against a pool's VM.
+type Compiler struct {
+	// Environ provides a set of environment variables that are merged
+	// into every step's environment.
+	Environ provider.Provider
+	// Secret resolves named secrets referenced by the pipeline. Build a
+	// plugin-backed provider with internal/secret/plugin and merge it
+	// with a static fallback via secret.Combine to support both.
+	Secret secret.Provider
+	// Pools holds the pool definitions parsed from the pool YAML file,
+	// keyed by name. Compile does not read it: the pipeline's `pool`
+	// attribute is not resolved and the compiled spec's Pool is left empty.
+	Pools map[string]poolfile.Pool
+	// Transforms run, in order, after the base spec is assembled and
+	// before Compile returns it, letting callers extend the compile
+	// step without forking it.
+	Transforms []Transform
+}
+
+// Compile converts the YAML pipeline manifest into an engine.Spec.
+func (c *Compiler) Compile(ctx context.Context, args runtime.CompilerArgs) runtime.Spec {
+	pipeline := args.Pipeline.(*resource.Pipeline)
+
+	spec := &engine.Spec{}
+
+	envs := map[string]string{}
+	if c.Environ != nil {
+		if got, err := c.Environ.List(ctx, &provider.Request{Build: args.Build, Repo: args.Repo}); err == nil {
+			for _, e := range got {
+				envs[e.Name] = e.Data
+			}
+		}
+	}
+
+	for _, src := range pipeline.Steps {
+		src.When.Evaluate(args.Build)
+		step := &engine.Step{
+			Name:      src.Name,
+			Image:     src.Image,
+			Command:   src.Commands,
+			Envs:      mergeEnv(envs, src.Environment),
+			RunPolicy: stepRunPolicy(src),
+		}
+		step.Secrets = c.findSecrets(ctx, args, src.Environment)
+		spec.Steps = append(spec.Steps, step)
+	}
+
+	if err := ApplyTransforms(spec, c.Transforms); err != nil {
+		logrus.WithError(err).Errorln("compiler: transform pipeline failed")
+	}
+
+	return spec
+}
+
+// findSecrets treats every value in env as the name of a secret and
+// resolves it into an engine.Secret for that variable, masked for log
+// scrubbing. Unresolved secrets are still returned so the step records
+// that the name was referenced, with Data left nil. With no Secret
+// provider configured, no secrets are returned.
+func (c *Compiler) findSecrets(ctx context.Context, args runtime.CompilerArgs, env map[string]string) []*engine.Secret {
+	var out []*engine.Secret
+	if c.Secret == nil {
+		return out
+	}
+	for key, name := range env {
+		found, err := c.Secret.Find(ctx, &secret.Request{
+			Name:  name,
+			Build: args.Build,
+			Repo:  args.Repo,
+			Conf:  args.Manifest,
+		})
+		if err != nil || found == nil {
+			out = append(out, &engine.Secret{Name: name, Env: key, Mask: true})
+			continue
+		}
+		out = append(out, &engine.Secret{
+			Name: name,
+			Env:  key,
+			Data: []byte(found.Data),
+			Mask: true,
+		})
+	}
+	return out
+}
+
+func mergeEnv(base, override map[string]string) map[string]string {
+	out := make(map[string]string, len(base)+len(override))
+	for k, v := range base {
+		out[k] = v
+	}
+	for k, v := range override {
+		out[k] = v
+	}
+	return out
+}
+
+func stepRunPolicy(src *resource.Step) runtime.RunPolicy {
+	switch {
+	case src.When.Failure() && src.When.Success():
+		return runtime.RunAlways
+	case src.When.Failure():
+		return runtime.RunOnFailure
+	case !src.When.Match():
+		return runtime.RunNever
+	default:
+		return runtime.RunOnSuccess
+	}
+}
@@ -0,0 +1,30 @@
+// Copyright 2020 Drone.IO Inc. All rights reserved.
+// Use of this source code is governed by the Polyform License
+// that can be found in the LICENSE file.
+
+package compiler
+
+import (
+	"github.com/drone-runners/drone-runner-aws/command/config"
+	"github.com/drone-runners/drone-runner-aws/internal/secret/plugin"
+
+	"github.com/drone/runner-go/secret"
+)
+
+// NewSecretProvider builds the secret.Provider to use as Compiler.Secret:
+// the HTTP secret plugin is tried first, falling back to fallback (e.g. a
+// secret.StaticVars built from the runner's own config) for any name the
+// plugin doesn't resolve, including when no plugin endpoint is
+// configured at all.
+func NewSecretProvider(cfg config.SecretPluginConfig, fallback secret.Provider) secret.Provider {
+	pluginProvider := plugin.New(plugin.Config{
+		Endpoint:   cfg.Endpoint,
+		HMACKey:    cfg.HMACKey,
+		SkipVerify: cfg.SkipVerify,
+		Timeout:    cfg.Timeout,
+	})
+	if fallback == nil {
+		return pluginProvider
+	}
+	return secret.Combine(pluginProvider, fallback)
+}
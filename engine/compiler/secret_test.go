@@ -0,0 +1,81 @@
+// Copyright 2020 Drone.IO Inc. All rights reserved.
+// Use of this source code is governed by the Polyform License
+// that can be found in the LICENSE file.
+
+package compiler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/drone-runners/drone-runner-aws/command/config"
+	"github.com/drone-runners/drone-runner-aws/engine"
+	"github.com/drone-runners/drone-runner-aws/engine/resource"
+	"github.com/drone-runners/drone-runner-aws/internal/poolfile"
+
+	"github.com/drone/drone-go/drone"
+	"github.com/drone/runner-go/environ/provider"
+	"github.com/drone/runner-go/manifest"
+	"github.com/drone/runner-go/pipeline/runtime"
+	"github.com/drone/runner-go/secret"
+)
+
+// This test verifies that a secret resolved by the HTTP secret plugin
+// flows through Compile into the step's secrets, merging with a static
+// fallback for names the plugin doesn't know about.
+func TestCompile_Secrets_Plugin(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Name string `json:"name"`
+		}
+		json.NewDecoder(r.Body).Decode(&req) //nolint:errcheck
+		if req.Name != "plugin_secret" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{ //nolint:errcheck
+			"data":                 "from-plugin",
+			"pull_request_allowed": true,
+		})
+	}))
+	defer srv.Close()
+
+	secretProvider := NewSecretProvider(
+		config.SecretPluginConfig{Endpoint: srv.URL, HMACKey: "topsecret"},
+		secret.StaticVars(map[string]string{"static_secret": "from-static"}),
+	)
+
+	mnfst, err := manifest.ParseFile("testdata/secret_plugin.yml")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	pools, _ := poolfile.ProcessPoolFile("testdata/drone_pool.yml", &poolfile.PoolSettings{})
+
+	compiler := &Compiler{
+		Environ: provider.Static(nil),
+		Secret:  secretProvider,
+		Pools:   pools,
+	}
+	args := runtime.CompilerArgs{
+		Repo:     &drone.Repo{},
+		Build:    &drone.Build{},
+		Stage:    &drone.Stage{},
+		Manifest: mnfst,
+		Pipeline: mnfst.Resources[0].(*resource.Pipeline),
+	}
+
+	ir := compiler.Compile(nocontext, args).(*engine.Spec)
+
+	got := map[string]string{}
+	for _, s := range ir.Steps[0].Secrets {
+		got[s.Env] = string(s.Data)
+	}
+	if got["PLUGIN_ENV"] != "from-plugin" {
+		t.Errorf("want plugin-resolved secret, got %q", got["PLUGIN_ENV"])
+	}
+	if got["STATIC_ENV"] != "from-static" {
+		t.Errorf("want static-fallback secret, got %q", got["STATIC_ENV"])
+	}
+}
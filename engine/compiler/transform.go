@@ -0,0 +1,28 @@
+// Copyright 2020 Drone.IO Inc. All rights reserved.
+// Use of this source code is governed by the Polyform License
+// that can be found in the LICENSE file.
+
+package compiler
+
+import "github.com/drone-runners/drone-runner-aws/engine"
+
+// Transform mutates a compiled engine.Spec in place. Transforms run in the
+// order they are configured, after the base compile pass and before the
+// spec is handed back to the caller, so each one sees the output of the
+// one before it.
+type Transform interface {
+	Visit(spec *engine.Spec) error
+}
+
+// ApplyTransforms runs each transform over spec in order, stopping and
+// returning the first error encountered. It is called by Compiler.Compile
+// once the base spec has been assembled, with Compiler.Transforms as the
+// transform list.
+func ApplyTransforms(spec *engine.Spec, transforms []Transform) error {
+	for _, t := range transforms {
+		if err := t.Visit(spec); err != nil {
+			return err
+		}
+	}
+	return nil
+}
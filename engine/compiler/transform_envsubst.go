@@ -0,0 +1,51 @@
+// Copyright 2020 Drone.IO Inc. All rights reserved.
+// Use of this source code is governed by the Polyform License
+// that can be found in the LICENSE file.
+
+package compiler
+
+import (
+	"os"
+
+	"github.com/drone-runners/drone-runner-aws/engine"
+)
+
+// unresolved is returned for any variable not present in a step's merged
+// environment, so pipeline commands can never pull in the runner process's
+// own environment by accident.
+const unresolved = ""
+
+// EnvsubstTransform expands ${VAR} / $VAR references in step commands,
+// image names, and env values, mirroring the envsubst pass in the classic
+// Drone compiler. Each step is expanded against its own merged
+// build/repo/stage environment (engine.Step.Envs), so a step only ever
+// sees variables it was already allowed to see.
+type EnvsubstTransform struct{}
+
+func (EnvsubstTransform) Visit(spec *engine.Spec) error {
+	for _, step := range spec.Steps {
+		expand := expander(step.Envs)
+		step.Image = expand(step.Image)
+		for i, cmd := range step.Command {
+			step.Command[i] = expand(cmd)
+		}
+		for k, v := range step.Envs {
+			step.Envs[k] = expand(v)
+		}
+	}
+	return nil
+}
+
+// expander returns a function that substitutes ${VAR} / $VAR references
+// using env; anything env does not define expands to unresolved (an
+// empty string), never to the process environment.
+func expander(env map[string]string) func(string) string {
+	return func(s string) string {
+		return os.Expand(s, func(key string) string {
+			if v, ok := env[key]; ok {
+				return v
+			}
+			return unresolved
+		})
+	}
+}
@@ -0,0 +1,36 @@
+// Copyright 2020 Drone.IO Inc. All rights reserved.
+// Use of this source code is governed by the Polyform License
+// that can be found in the LICENSE file.
+
+package compiler
+
+import "github.com/drone-runners/drone-runner-aws/engine"
+
+// Limits caps the compute resources a step is allowed to use, mirroring
+// the Limits struct used by the Drone operator runner.
+type Limits struct {
+	// MemLimit is the memory limit in bytes. Zero means unlimited.
+	MemLimit int64
+	// CPUQuota is the CPU quota in microseconds per CPU period. Zero
+	// means unlimited.
+	CPUQuota int64
+}
+
+// ResourceLimitTransform injects a default memory/CPU limit into every
+// step that does not already declare one, so a single runaway step can't
+// starve the rest of the pool's VM.
+type ResourceLimitTransform struct {
+	Limits Limits
+}
+
+func (t ResourceLimitTransform) Visit(spec *engine.Spec) error {
+	for _, step := range spec.Steps {
+		if step.MemLimit == 0 {
+			step.MemLimit = t.Limits.MemLimit
+		}
+		if step.CPUQuota == 0 {
+			step.CPUQuota = t.Limits.CPUQuota
+		}
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+// Copyright 2020 Drone.IO Inc. All rights reserved.
+// Use of this source code is governed by the Polyform License
+// that can be found in the LICENSE file.
+
+package compiler
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/drone-runners/drone-runner-aws/engine"
+)
+
+// Resolver looks up the immutable digest for an image reference, e.g.
+// "foo:tag" -> "sha256:...". Implementations typically query a registry
+// or a local cache.
+type Resolver interface {
+	Resolve(image string) (digest string, err error)
+}
+
+// ImagePinTransform rewrites each step's image reference from a mutable
+// tag to an immutable digest, e.g. "image:tag" becomes
+// "image@sha256:...", using the supplied Resolver. Images that are
+// already pinned, or that the resolver cannot resolve, are left alone.
+type ImagePinTransform struct {
+	Resolver Resolver
+}
+
+func (t ImagePinTransform) Visit(spec *engine.Spec) error {
+	if t.Resolver == nil {
+		return nil
+	}
+	for _, step := range spec.Steps {
+		pinned, err := t.pin(step.Image)
+		if err != nil {
+			return fmt.Errorf("pin image %s: %w", step.Image, err)
+		}
+		step.Image = pinned
+	}
+	return nil
+}
+
+func (t ImagePinTransform) pin(image string) (string, error) {
+	if image == "" || strings.Contains(image, "@sha256:") {
+		return image, nil
+	}
+	digest, err := t.Resolver.Resolve(image)
+	if err != nil {
+		return image, nil //nolint:nilerr // unresolvable images are left as-is, not fatal
+	}
+	if digest == "" {
+		return image, nil
+	}
+	name := image
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		name = image[:i]
+	}
+	return fmt.Sprintf("%s@%s", name, digest), nil
+}
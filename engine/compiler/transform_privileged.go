@@ -0,0 +1,31 @@
+// Copyright 2020 Drone.IO Inc. All rights reserved.
+// Use of this source code is governed by the Polyform License
+// that can be found in the LICENSE file.
+
+package compiler
+
+import "github.com/drone-runners/drone-runner-aws/engine"
+
+// PrivilegedTransform flips Step.Privileged to true only when the step's
+// image matches one of Images, regardless of what the pipeline YAML
+// requested. It never turns an already-privileged step off, and never
+// grants privileged mode to an image outside the allow list.
+type PrivilegedTransform struct {
+	Images []string
+}
+
+func (t PrivilegedTransform) Visit(spec *engine.Spec) error {
+	if len(t.Images) == 0 {
+		return nil
+	}
+	allowed := make(map[string]struct{}, len(t.Images))
+	for _, image := range t.Images {
+		allowed[image] = struct{}{}
+	}
+	for _, step := range spec.Steps {
+		if _, ok := allowed[step.Image]; ok {
+			step.Privileged = true
+		}
+	}
+	return nil
+}
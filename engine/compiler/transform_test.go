@@ -0,0 +1,123 @@
+// Copyright 2020 Drone.IO Inc. All rights reserved.
+// Use of this source code is governed by the Polyform License
+// that can be found in the LICENSE file.
+
+package compiler
+
+import (
+	"testing"
+
+	"github.com/drone-runners/drone-runner-aws/engine"
+)
+
+func TestEnvsubstTransform(t *testing.T) {
+	spec := &engine.Spec{
+		Steps: []*engine.Step{
+			{
+				Image:   "golang:${GO_VERSION}",
+				Command: []string{"go build ${PKG}"},
+				Envs:    map[string]string{"GO_VERSION": "1.21", "PKG": "./..."},
+			},
+		},
+	}
+
+	if err := (EnvsubstTransform{}).Visit(spec); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	step := spec.Steps[0]
+	if step.Image != "golang:1.21" {
+		t.Errorf("want image golang:1.21, got %s", step.Image)
+	}
+	if step.Command[0] != "go build ./..." {
+		t.Errorf("want expanded command, got %s", step.Command[0])
+	}
+}
+
+type fakeResolver map[string]string
+
+func (f fakeResolver) Resolve(image string) (string, error) {
+	return f[image], nil
+}
+
+func TestImagePinTransform(t *testing.T) {
+	spec := &engine.Spec{
+		Steps: []*engine.Step{
+			{Image: "golang:1.21"},
+			{Image: "already@sha256:deadbeef"},
+			{Image: "unknown:latest"},
+		},
+	}
+
+	resolver := fakeResolver{"golang:1.21": "sha256:abc123"}
+	if err := (ImagePinTransform{Resolver: resolver}).Visit(spec); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if spec.Steps[0].Image != "golang@sha256:abc123" {
+		t.Errorf("want pinned image, got %s", spec.Steps[0].Image)
+	}
+	if spec.Steps[1].Image != "already@sha256:deadbeef" {
+		t.Errorf("want already-pinned image unchanged, got %s", spec.Steps[1].Image)
+	}
+	if spec.Steps[2].Image != "unknown:latest" {
+		t.Errorf("want unresolvable image left as-is, got %s", spec.Steps[2].Image)
+	}
+}
+
+func TestPrivilegedTransform(t *testing.T) {
+	spec := &engine.Spec{
+		Steps: []*engine.Step{
+			{Image: "docker:dind"},
+			{Image: "golang:1.21"},
+		},
+	}
+
+	if err := (PrivilegedTransform{Images: []string{"docker:dind"}}).Visit(spec); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if !spec.Steps[0].Privileged {
+		t.Errorf("want docker:dind to be privileged")
+	}
+	if spec.Steps[1].Privileged {
+		t.Errorf("want golang:1.21 to stay unprivileged")
+	}
+}
+
+func TestResourceLimitTransform(t *testing.T) {
+	spec := &engine.Spec{
+		Steps: []*engine.Step{
+			{Image: "golang:1.21"},
+			{Image: "golang:1.21", MemLimit: 512},
+		},
+	}
+
+	transform := ResourceLimitTransform{Limits: Limits{MemLimit: 1024, CPUQuota: 100000}}
+	if err := transform.Visit(spec); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if spec.Steps[0].MemLimit != 1024 {
+		t.Errorf("want default mem limit applied, got %d", spec.Steps[0].MemLimit)
+	}
+	if spec.Steps[1].MemLimit != 512 {
+		t.Errorf("want existing mem limit preserved, got %d", spec.Steps[1].MemLimit)
+	}
+}
+
+func TestApplyTransformsRunsInOrder(t *testing.T) {
+	spec := &engine.Spec{Steps: []*engine.Step{{Image: "docker:dind"}}}
+
+	transforms := []Transform{
+		PrivilegedTransform{Images: []string{"docker:dind"}},
+		ResourceLimitTransform{Limits: Limits{MemLimit: 256}},
+	}
+	if err := ApplyTransforms(spec, transforms); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if !spec.Steps[0].Privileged || spec.Steps[0].MemLimit != 256 {
+		t.Errorf("want both transforms applied, got %+v", spec.Steps[0])
+	}
+}
@@ -0,0 +1,158 @@
+// Copyright 2020 Drone.IO Inc. All rights reserved.
+// Use of this source code is governed by the Polyform License
+// that can be found in the LICENSE file.
+
+// Package resource defines the YAML schema for the vm pipeline resource
+// that drone-runner-aws compiles and executes.
+package resource
+
+import (
+	"github.com/drone/drone-go/drone"
+	"github.com/drone/runner-go/manifest"
+)
+
+// Kind and Type identify this resource within a multi-document YAML
+// manifest.
+const (
+	Kind = "pipeline"
+	Type = "vm"
+)
+
+func init() {
+	manifest.Register(Kind, Type, func() manifest.Resource {
+		return new(Pipeline)
+	})
+}
+
+// Pipeline is a pipeline resource parsed from the pipeline YAML.
+type Pipeline struct {
+	Version string
+	Kind    string  `yaml:"kind"`
+	Type    string  `yaml:"type"`
+	Name    string  `yaml:"name"`
+	Pool    string  `yaml:"pool"`
+	Clone   Clone   `yaml:"clone"`
+	Steps   []*Step `yaml:"steps"`
+}
+
+func (p *Pipeline) GetVersion() string { return p.Version }
+func (p *Pipeline) GetKind() string    { return Kind }
+func (p *Pipeline) GetType() string    { return Type }
+func (p *Pipeline) GetName() string    { return p.Name }
+
+// Clone configures the implicit clone step.
+type Clone struct {
+	Disable bool `yaml:"disable"`
+}
+
+// Step is a single step in the pipeline.
+type Step struct {
+	Name        string            `yaml:"name"`
+	Image       string            `yaml:"image"`
+	Commands    []string          `yaml:"commands"`
+	Environment map[string]string `yaml:"environment"`
+	When        Conditions        `yaml:"when"`
+}
+
+// Conditions gates whether a step runs, matching the pipeline's `when`
+// block.
+type Conditions struct {
+	Branch Condition `yaml:"branch"`
+	Event  Condition `yaml:"event"`
+	Status []string  `yaml:"status"`
+
+	// Matched records whether Branch/Event matched the build that is
+	// being compiled. It is set by Evaluate, not by YAML decoding; the
+	// zero value is a placeholder until Evaluate runs.
+	Matched bool `yaml:"-"`
+}
+
+// Evaluate resolves Branch/Event against build and records the result
+// in Matched. It must be called once per compiled build before Match is
+// consulted.
+func (c *Conditions) Evaluate(build *drone.Build) {
+	c.Matched = c.Branch.match(build.Target) && c.Event.match(build.Event)
+}
+
+// Success reports whether the step is configured to run on success. The
+// zero value defaults to true, matching an omitted `when` block.
+func (c Conditions) Success() bool {
+	if len(c.Status) == 0 {
+		return true
+	}
+	return containsString(c.Status, "success")
+}
+
+// Failure reports whether the step is configured to also run on failure.
+func (c Conditions) Failure() bool {
+	return containsString(c.Status, "failure")
+}
+
+// Match reports whether the step's `when` conditions are satisfied for
+// this build, independent of success/failure policy. It reflects the
+// result of the most recent call to Evaluate.
+func (c Conditions) Match() bool {
+	return c.Matched
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// Condition matches a single build attribute (e.g. branch or event)
+// against an include/exclude list. It can be configured in YAML as a
+// single scalar, a list of values, or an explicit include/exclude map;
+// an empty Condition always matches.
+type Condition struct {
+	Include []string
+	Exclude []string
+}
+
+// UnmarshalYAML accepts a scalar string, a list of strings, or a map
+// with include/exclude keys.
+func (c *Condition) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var single string
+	if err := unmarshal(&single); err == nil {
+		c.Include = []string{single}
+		return nil
+	}
+
+	var list []string
+	if err := unmarshal(&list); err == nil {
+		c.Include = list
+		return nil
+	}
+
+	var verbose struct {
+		Include []string `yaml:"include"`
+		Exclude []string `yaml:"exclude"`
+	}
+	if err := unmarshal(&verbose); err != nil {
+		return err
+	}
+	c.Include = verbose.Include
+	c.Exclude = verbose.Exclude
+	return nil
+}
+
+func (c Condition) match(v string) bool {
+	for _, exclude := range c.Exclude {
+		if exclude == v {
+			return false
+		}
+	}
+	if len(c.Include) == 0 {
+		return true
+	}
+	for _, include := range c.Include {
+		if include == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,81 @@
+// Copyright 2020 Drone.IO Inc. All rights reserved.
+// Use of this source code is governed by the Polyform License
+// that can be found in the LICENSE file.
+
+package resource
+
+import (
+	"testing"
+
+	"github.com/drone/drone-go/drone"
+	"gopkg.in/yaml.v2"
+)
+
+func TestConditionUnmarshalScalar(t *testing.T) {
+	var c Condition
+	if err := yaml.Unmarshal([]byte("master"), &c); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(c.Include) != 1 || c.Include[0] != "master" {
+		t.Errorf("want Include [master], got %+v", c.Include)
+	}
+}
+
+func TestConditionUnmarshalList(t *testing.T) {
+	var c Condition
+	if err := yaml.Unmarshal([]byte("[master, develop]"), &c); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(c.Include) != 2 {
+		t.Errorf("want 2 includes, got %+v", c.Include)
+	}
+}
+
+func TestConditionUnmarshalIncludeExclude(t *testing.T) {
+	var c Condition
+	if err := yaml.Unmarshal([]byte("include: [master]\nexclude: [release/*]"), &c); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(c.Include) != 1 || c.Include[0] != "master" {
+		t.Errorf("want Include [master], got %+v", c.Include)
+	}
+	if len(c.Exclude) != 1 || c.Exclude[0] != "release/*" {
+		t.Errorf("want Exclude [release/*], got %+v", c.Exclude)
+	}
+}
+
+func TestConditionsEvaluateNoWhenBlockMatches(t *testing.T) {
+	var c Conditions
+	c.Evaluate(&drone.Build{Target: "master", Event: "push"})
+	if !c.Match() {
+		t.Errorf("want an omitted when block to match every build")
+	}
+}
+
+func TestConditionsEvaluateBranchMismatch(t *testing.T) {
+	c := Conditions{Branch: Condition{Include: []string{"develop"}}}
+	c.Evaluate(&drone.Build{Target: "master"})
+	if c.Match() {
+		t.Errorf("want branch mismatch to not match")
+	}
+}
+
+func TestConditionsSuccessFailureDefaults(t *testing.T) {
+	var c Conditions
+	if !c.Success() {
+		t.Errorf("want Success to default true with no status configured")
+	}
+	if c.Failure() {
+		t.Errorf("want Failure to default false with no status configured")
+	}
+}
+
+func TestConditionsStatusList(t *testing.T) {
+	c := Conditions{Status: []string{"failure"}}
+	if c.Success() {
+		t.Errorf("want Success false when status omits it")
+	}
+	if !c.Failure() {
+		t.Errorf("want Failure true when status includes it")
+	}
+}
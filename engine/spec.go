@@ -0,0 +1,83 @@
+// Copyright 2020 Drone.IO Inc. All rights reserved.
+// Use of this source code is governed by the Polyform License
+// that can be found in the LICENSE file.
+
+package engine
+
+import (
+	"github.com/drone/runner-go/pipeline/runtime"
+)
+
+// Spec provides the pipeline spec compiled from the pipeline YAML. It is
+// handed to the Engine to create a pipeline state and execute steps
+// against the pool's VM.
+type Spec struct {
+	Steps []*Step
+	Pool  Pool
+}
+
+// StepLen returns the number of steps. It, along with StepAt, satisfies
+// runtime.Spec so the spec can be driven by the pipeline executor.
+func (s *Spec) StepLen() int {
+	return len(s.Steps)
+}
+
+// StepAt returns the step at index i.
+func (s *Spec) StepAt(i int) runtime.Step {
+	return s.Steps[i]
+}
+
+// Pool describes the VM the pipeline runs on.
+type Pool struct {
+	Instance Instance
+}
+
+// Instance holds the connection material for the VM backing a pool,
+// provisioned ahead of the pipeline run.
+type Instance struct {
+	PrivateKey string
+	PublicKey  string
+	UserData   string
+}
+
+// Step is a single pipeline step compiled from the YAML.
+type Step struct {
+	Name       string
+	Image      string
+	Command    []string
+	Entrypoint []string
+	Envs       map[string]string
+	Secrets    []*Secret
+	Privileged bool
+	MemLimit   int64
+	CPUQuota   int64
+	RunPolicy  runtime.RunPolicy
+	DependsOn  []string
+	Detach     bool
+}
+
+func (s *Step) GetName() string                  { return s.Name }
+func (s *Step) GetDependencies() []string        { return s.DependsOn }
+func (s *Step) GetEnviron() map[string]string    { return s.Envs }
+func (s *Step) SetEnviron(env map[string]string) { s.Envs = env }
+func (s *Step) GetErrIgnore() bool               { return s.RunPolicy == runtime.RunAlways }
+func (s *Step) GetOnFailure() bool {
+	return s.RunPolicy == runtime.RunOnFailure || s.RunPolicy == runtime.RunAlways
+}
+func (s *Step) GetRunPolicy() runtime.RunPolicy  { return s.RunPolicy }
+func (s *Step) IsDetached() bool                 { return s.Detach }
+func (s *Step) SecretLen() int                   { return len(s.Secrets) }
+func (s *Step) GetSecretAt(i int) runtime.Secret { return s.Secrets[i] }
+
+// Secret is a secret resolved for a step, ready to be injected into the
+// step's environment as Env.
+type Secret struct {
+	Name string
+	Env  string
+	Data []byte
+	Mask bool
+}
+
+func (s *Secret) GetName() string  { return s.Name }
+func (s *Secret) GetValue() string { return string(s.Data) }
+func (s *Secret) IsMasked() bool   { return s.Mask }
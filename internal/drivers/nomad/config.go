@@ -0,0 +1,50 @@
+// Copyright 2020 Drone.IO Inc. All rights reserved.
+// Use of this source code is governed by the Polyform License
+// that can be found in the LICENSE file.
+
+package nomad
+
+import "time"
+
+// Config holds the settings needed to submit and track jobs against
+// a HashiCorp Nomad cluster on behalf of a single pool.
+type Config struct {
+	// Address is the HTTP address of the Nomad API, e.g. http://nomad.internal:4646.
+	Address string `json:"address" yaml:"address"`
+	// Region is the Nomad region the job should be scheduled in. Optional,
+	// Nomad defaults to "global" when empty.
+	Region string `json:"region,omitempty" yaml:"region,omitempty"`
+	// Datacenters restricts scheduling to the given datacenters. Optional,
+	// Nomad schedules across all datacenters in the region when empty.
+	Datacenters []string `json:"datacenters,omitempty" yaml:"datacenters,omitempty"`
+	// Namespace is the Nomad namespace the job is registered under.
+	Namespace string `json:"namespace,omitempty" yaml:"namespace,omitempty"`
+	// Token is an optional ACL token used to authenticate against Nomad.
+	Token string `json:"token,omitempty" yaml:"token,omitempty"`
+
+	// TaskDriver selects the Nomad task driver used to run the VM, e.g.
+	// "qemu" or "raw_exec".
+	TaskDriver string `json:"task_driver" yaml:"task_driver"`
+	// Image is passed through to the task config as the VM image/artifact
+	// to boot (qemu disk image, raw_exec binary, etc).
+	Image string `json:"image,omitempty" yaml:"image,omitempty"`
+
+	// PollInterval is how often the driver polls allocation status while
+	// waiting for a job to become running.
+	PollInterval time.Duration `json:"poll_interval,omitempty" yaml:"poll_interval,omitempty"`
+	// PollTimeout bounds how long the driver waits for an allocation to
+	// reach the running state before giving up.
+	PollTimeout time.Duration `json:"poll_timeout,omitempty" yaml:"poll_timeout,omitempty"`
+}
+
+func (c *Config) setDefaults() {
+	if c.PollInterval <= 0 {
+		c.PollInterval = 2 * time.Second
+	}
+	if c.PollTimeout <= 0 {
+		c.PollTimeout = 5 * time.Minute
+	}
+	if c.TaskDriver == "" {
+		c.TaskDriver = "qemu"
+	}
+}
@@ -0,0 +1,305 @@
+// Copyright 2020 Drone.IO Inc. All rights reserved.
+// Use of this source code is governed by the Polyform License
+// that can be found in the LICENSE file.
+
+// Package nomad implements a drivers.Driver that provisions and reaps VMs
+// by submitting jobs to a HashiCorp Nomad cluster, instead of calling a
+// cloud provider API directly. It lets operators who already run Nomad
+// reuse it as the VM scheduler for Drone/Harness pipelines.
+package nomad
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/drone-runners/drone-runner-aws/types"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const driverName = "nomad"
+
+// provider implements drivers.Driver on top of the Nomad HTTP API.
+type provider struct {
+	config     Config
+	client     *http.Client
+	runnerName string
+}
+
+// New returns a drivers.Driver that schedules pool instances as Nomad jobs.
+func New(runnerName string, config Config) (*provider, error) {
+	if config.Address == "" {
+		return nil, errors.New("nomad: address is required")
+	}
+	config.setDefaults()
+	return &provider{
+		config:     config,
+		client:     &http.Client{Timeout: 30 * time.Second},
+		runnerName: runnerName,
+	}, nil
+}
+
+func (p *provider) RootDir() string    { return "" }
+func (p *provider) DriverName() string { return driverName }
+func (p *provider) CanHibernate() bool { return false }
+
+// Ping checks that the Nomad agent is reachable.
+func (p *provider) Ping(ctx context.Context) error {
+	_, err := p.do(ctx, http.MethodGet, "/v1/agent/self", nil)
+	return err
+}
+
+// Create registers a Nomad job for the pool's task driver/image, polls the
+// resulting allocation until it is running, and returns an Instance whose
+// ID is the Nomad allocation ID and whose Address/Port are discovered from
+// the allocation's network resources so lehelper.GetClient can reach the
+// lite-engine running inside it.
+func (p *provider) Create(ctx context.Context, opts *types.InstanceCreateOpts) (*types.Instance, error) {
+	jobID := fmt.Sprintf("%s-%s", opts.PoolName, opts.RunnerName)
+	job := p.buildJob(jobID, opts)
+
+	if _, err := p.do(ctx, http.MethodPost, "/v1/jobs", map[string]interface{}{"Job": job}); err != nil {
+		return nil, errors.Wrap(err, "nomad: failed to register job")
+	}
+
+	alloc, err := p.waitForAllocation(ctx, jobID)
+	if err != nil {
+		return nil, errors.Wrap(err, "nomad: failed waiting for allocation to start")
+	}
+
+	host, port := alloc.network()
+
+	return &types.Instance{
+		ID:       alloc.ID,
+		Name:     jobID,
+		Provider: types.ProviderType(driverName),
+		Address:  host,
+		Port:     port,
+		OS:       opts.OS,
+		Arch:     opts.Arch,
+		Pool:     opts.PoolName,
+	}, nil
+}
+
+// Destroy deregisters the Nomad jobs backing the given instance IDs.
+// instanceIDs are Nomad allocation IDs, matching what Create stored as
+// types.Instance.ID; Nomad itself deregisters by job ID, so each
+// allocation ID is resolved to its owning job ID first.
+func (p *provider) Destroy(ctx context.Context, instanceIDs []string) error {
+	var firstErr error
+	for _, allocID := range instanceIDs {
+		jobID, err := p.jobIDForAllocation(ctx, allocID)
+		if err != nil {
+			logrus.WithError(err).WithField("allocation_id", allocID).Errorln("nomad: failed to resolve job ID for allocation")
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+
+		path := fmt.Sprintf("/v1/job/%s?purge=true", jobID)
+		if _, err := p.do(ctx, http.MethodDelete, path, nil); err != nil {
+			logrus.WithError(err).WithField("job_id", jobID).WithField("allocation_id", allocID).
+				Errorln("nomad: failed to deregister job")
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	return firstErr
+}
+
+// jobIDForAllocation looks up the Nomad job ID that owns the given
+// allocation ID.
+func (p *provider) jobIDForAllocation(ctx context.Context, allocID string) (string, error) {
+	body, err := p.do(ctx, http.MethodGet, fmt.Sprintf("/v1/allocation/%s", allocID), nil)
+	if err != nil {
+		return "", err
+	}
+	var alloc struct {
+		JobID string `json:"JobID"`
+	}
+	if err := json.Unmarshal(body, &alloc); err != nil {
+		return "", errors.Wrap(err, "nomad: failed to decode allocation")
+	}
+	if alloc.JobID == "" {
+		return "", errors.Errorf("nomad: allocation %s has no job ID", allocID)
+	}
+	return alloc.JobID, nil
+}
+
+func (p *provider) Hibernate(ctx context.Context, instanceID, poolName string) error {
+	return errors.New("nomad: hibernate is not supported")
+}
+
+func (p *provider) Start(ctx context.Context, instanceID, poolName string) (string, error) {
+	return "", errors.New("nomad: start is not supported")
+}
+
+// Logs returns the stdout of the allocation's task, which is the closest
+// Nomad analogue to a cloud instance's console log.
+func (p *provider) Logs(ctx context.Context, instanceID string) (string, error) {
+	path := fmt.Sprintf("/v1/client/fs/logs/%s?task=%s&type=stdout&plain=true", instanceID, "lite-engine")
+	body, err := p.do(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return "", err
+	}
+	return string(body), nil
+}
+
+func (p *provider) SetTags(ctx context.Context, instance *types.Instance, tags map[string]string) error {
+	// Nomad jobs don't carry an arbitrary tag bag, so tags are not applied;
+	// the job's Meta block holds only the pool and runner names set at
+	// registration time.
+	return nil
+}
+
+// allocation is the subset of the Nomad allocation API response this
+// driver needs.
+type allocation struct {
+	ID           string `json:"ID"`
+	ClientStatus string `json:"ClientStatus"`
+	Resources    struct {
+		Networks []struct {
+			IP            string `json:"IP"`
+			ReservedPorts []struct {
+				Label string `json:"Label"`
+				Value int    `json:"Value"`
+			} `json:"ReservedPorts"`
+			DynamicPorts []struct {
+				Label string `json:"Label"`
+				Value int    `json:"Value"`
+			} `json:"DynamicPorts"`
+		} `json:"Networks"`
+	} `json:"AllocatedResources"`
+}
+
+func (a *allocation) network() (host string, port int64) {
+	for _, n := range a.Resources.Networks {
+		host = n.IP
+		for _, p := range n.ReservedPorts {
+			if p.Label == "lite-engine" {
+				return host, int64(p.Value)
+			}
+		}
+		for _, p := range n.DynamicPorts {
+			if p.Label == "lite-engine" {
+				return host, int64(p.Value)
+			}
+		}
+	}
+	return host, port
+}
+
+func (p *provider) waitForAllocation(ctx context.Context, jobID string) (*allocation, error) {
+	deadline := time.Now().Add(p.config.PollTimeout)
+	for {
+		allocs, err := p.allocationsForJob(ctx, jobID)
+		if err != nil {
+			return nil, err
+		}
+		for _, a := range allocs {
+			if a.ClientStatus == "running" {
+				return a, nil
+			}
+		}
+		if time.Now().After(deadline) {
+			return nil, errors.Errorf("nomad: job %s did not reach running state within %s", jobID, p.config.PollTimeout)
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(p.config.PollInterval):
+		}
+	}
+}
+
+func (p *provider) allocationsForJob(ctx context.Context, jobID string) ([]*allocation, error) {
+	body, err := p.do(ctx, http.MethodGet, fmt.Sprintf("/v1/job/%s/allocations", jobID), nil)
+	if err != nil {
+		return nil, err
+	}
+	var allocs []*allocation
+	if err := json.Unmarshal(body, &allocs); err != nil {
+		return nil, errors.Wrap(err, "nomad: failed to decode allocations")
+	}
+	return allocs, nil
+}
+
+// buildJob translates a pool's instance create options into a Nomad job
+// specification running a single task with the pool's configured task
+// driver (e.g. qemu or raw_exec).
+func (p *provider) buildJob(jobID string, opts *types.InstanceCreateOpts) map[string]interface{} {
+	task := map[string]interface{}{
+		"Name":   "lite-engine",
+		"Driver": p.config.TaskDriver,
+		"Config": map[string]interface{}{
+			"image_path": p.config.Image,
+		},
+	}
+
+	group := map[string]interface{}{
+		"Name":  jobID,
+		"Tasks": []interface{}{task},
+		"Networks": []interface{}{
+			map[string]interface{}{
+				"DynamicPorts": []interface{}{
+					map[string]interface{}{"Label": "lite-engine"},
+				},
+			},
+		},
+	}
+
+	job := map[string]interface{}{
+		"ID":          jobID,
+		"Name":        jobID,
+		"Type":        "batch",
+		"Region":      p.config.Region,
+		"Namespace":   p.config.Namespace,
+		"Datacenters": p.config.Datacenters,
+		"TaskGroups":  []interface{}{group},
+		"Meta": map[string]interface{}{
+			"pool":   opts.PoolName,
+			"runner": p.runnerName,
+		},
+	}
+	return job
+}
+
+func (p *provider) do(ctx context.Context, method, path string, payload interface{}) ([]byte, error) {
+	var body bytes.Buffer
+	if payload != nil {
+		if err := json.NewEncoder(&body).Encode(payload); err != nil {
+			return nil, errors.Wrap(err, "nomad: failed to encode request")
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, p.config.Address+path, &body)
+	if err != nil {
+		return nil, errors.Wrap(err, "nomad: failed to build request")
+	}
+	if p.config.Token != "" {
+		req.Header.Set("X-Nomad-Token", p.config.Token)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "nomad: request failed")
+	}
+	defer resp.Body.Close()
+
+	out := new(bytes.Buffer)
+	if _, err := out.ReadFrom(resp.Body); err != nil {
+		return nil, errors.Wrap(err, "nomad: failed to read response")
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		return nil, errors.Errorf("nomad: %s %s returned %d: %s", method, path, resp.StatusCode, out.String())
+	}
+	return out.Bytes(), nil
+}
@@ -0,0 +1,92 @@
+// Copyright 2020 Drone.IO Inc. All rights reserved.
+// Use of this source code is governed by the Polyform License
+// that can be found in the LICENSE file.
+
+package nomad
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/drone-runners/drone-runner-aws/types"
+)
+
+func TestConfigSetDefaults(t *testing.T) {
+	c := Config{Address: "http://nomad.internal:4646"}
+	c.setDefaults()
+
+	if c.TaskDriver != "qemu" {
+		t.Errorf("want default task driver qemu, got %s", c.TaskDriver)
+	}
+	if c.PollInterval != 2*time.Second {
+		t.Errorf("want default poll interval 2s, got %s", c.PollInterval)
+	}
+	if c.PollTimeout != 5*time.Minute {
+		t.Errorf("want default poll timeout 5m, got %s", c.PollTimeout)
+	}
+}
+
+func TestNewRequiresAddress(t *testing.T) {
+	if _, err := New("test-runner", Config{}); err == nil {
+		t.Errorf("expected error when address is empty")
+	}
+}
+
+func TestBuildJob(t *testing.T) {
+	p, err := New("test-runner", Config{
+		Address:     "http://nomad.internal:4646",
+		Region:      "global",
+		Datacenters: []string{"dc1"},
+		Namespace:   "drone",
+		TaskDriver:  "qemu",
+		Image:       "/images/linux-amd64.qcow2",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	job := p.buildJob("pool-a-test-runner", &types.InstanceCreateOpts{PoolName: "pool-a", RunnerName: "test-runner"})
+	if job["ID"] != "pool-a-test-runner" {
+		t.Errorf("want job ID pool-a-test-runner, got %v", job["ID"])
+	}
+	if job["Region"] != "global" {
+		t.Errorf("want region global, got %v", job["Region"])
+	}
+}
+
+// TestDestroyResolvesJobIDFromAllocation guards against instance IDs
+// (Nomad allocation IDs) being deregistered as if they were job IDs,
+// which would 404 against the Nomad API and leak every VM.
+func TestDestroyResolvesJobIDFromAllocation(t *testing.T) {
+	const allocID = "alloc-123"
+	const jobID = "pool-a-test-runner"
+
+	var deregisteredPath string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/allocation/"+allocID:
+			json.NewEncoder(w).Encode(map[string]string{"JobID": jobID}) //nolint:errcheck
+		case r.Method == http.MethodDelete:
+			deregisteredPath = r.URL.Path
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	p, err := New("test-runner", Config{Address: srv.URL})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if err := p.Destroy(context.Background(), []string{allocID}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if deregisteredPath != "/v1/job/"+jobID {
+		t.Errorf("want job %s deregistered, got path %q", jobID, deregisteredPath)
+	}
+}
@@ -0,0 +1,110 @@
+// Copyright 2020 Drone.IO Inc. All rights reserved.
+// Use of this source code is governed by the Polyform License
+// that can be found in the LICENSE file.
+
+// Package poolfile parses the pool YAML file that describes the VM pools
+// available to the compiler, and the credentials each pool's driver
+// needs to provision instances.
+package poolfile
+
+import (
+	"os"
+
+	"github.com/drone-runners/drone-runner-aws/internal/drivers/nomad"
+
+	"gopkg.in/yaml.v2"
+)
+
+// PoolSettings carries credentials that apply across every pool in the
+// file, e.g. cloud credentials supplied via flags/env rather than the
+// YAML itself.
+type PoolSettings struct {
+	AwsAccessKeyID     string
+	AwsAccessKeySecret string
+	AwsStackName       string
+}
+
+// Platform describes the OS/architecture a pool's instances run.
+type Platform struct {
+	OS   string `yaml:"os"`
+	Arch string `yaml:"arch"`
+}
+
+// Pool is a single pool entry parsed from the pool YAML file.
+type Pool struct {
+	Name     string   `yaml:"name"`
+	Default  bool     `yaml:"default"`
+	Type     string   `yaml:"type"`
+	MinPool  int      `yaml:"pool"`
+	Platform Platform `yaml:"platform"`
+
+	// Nomad is populated when Type == "nomad"; it configures the
+	// Nomad-backed driver instead of a cloud API driver.
+	Nomad *nomad.Config `yaml:"-"`
+}
+
+type rawFile struct {
+	Version   string `yaml:"version"`
+	Instances []struct {
+		Pool `yaml:",inline"`
+		Spec map[string]interface{} `yaml:"spec"`
+	} `yaml:"instances"`
+}
+
+// ProcessPoolFile reads and parses the pool YAML file at path, returning
+// the pools keyed by name. Driver-specific spec blocks (account,
+// task_driver, image, ...) are only fully decoded for drivers this
+// package knows how to configure; unknown drivers are kept by name so
+// callers can report a clear configuration error.
+func ProcessPoolFile(path string, settings *PoolSettings) (map[string]Pool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var raw rawFile
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+
+	pools := make(map[string]Pool, len(raw.Instances))
+	for _, inst := range raw.Instances {
+		p := inst.Pool
+		if p.Type == "nomad" {
+			p.Nomad = decodeNomadConfig(inst.Spec)
+		}
+		pools[p.Name] = p
+	}
+	return pools, nil
+}
+
+func decodeNomadConfig(spec map[string]interface{}) *nomad.Config {
+	cfg := &nomad.Config{}
+	account, _ := spec["account"].(map[interface{}]interface{})
+	if v, ok := account["address"].(string); ok {
+		cfg.Address = v
+	}
+	if v, ok := account["region"].(string); ok {
+		cfg.Region = v
+	}
+	if v, ok := account["namespace"].(string); ok {
+		cfg.Namespace = v
+	}
+	if v, ok := account["token"].(string); ok {
+		cfg.Token = v
+	}
+	if v, ok := account["datacenters"].([]interface{}); ok {
+		for _, dc := range v {
+			if s, ok := dc.(string); ok {
+				cfg.Datacenters = append(cfg.Datacenters, s)
+			}
+		}
+	}
+	if v, ok := spec["task_driver"].(string); ok {
+		cfg.TaskDriver = v
+	}
+	if v, ok := spec["image"].(string); ok {
+		cfg.Image = v
+	}
+	return cfg
+}
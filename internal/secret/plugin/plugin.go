@@ -0,0 +1,143 @@
+// Copyright 2020 Drone.IO Inc. All rights reserved.
+// Use of this source code is governed by the Polyform License
+// that can be found in the LICENSE file.
+
+// Package plugin implements a secret.Provider that resolves secrets by
+// calling out to an external HTTP secret plugin, speaking the same
+// protocol as the classic Drone secret extension: Vault, AWS Secrets
+// Manager, GCP Secret Manager, etc. can all sit behind it without the
+// runner needing to be recompiled.
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/drone/drone-go/drone"
+	"github.com/drone/runner-go/secret"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Config configures a single HTTP secret plugin endpoint. It is set per
+// runner; the pool file has no per-pool setting that overrides it.
+type Config struct {
+	// Endpoint is the URL the plugin's secret extension is served from.
+	Endpoint string
+	// HMACKey is the shared secret used to sign each request.
+	HMACKey string
+	// SkipVerify disables TLS certificate verification, for
+	// self-signed endpoints.
+	SkipVerify bool
+	// Timeout bounds how long a single lookup is allowed to take.
+	Timeout time.Duration
+}
+
+// request is the payload POSTed to the plugin endpoint.
+type request struct {
+	Repo  *drone.Repo  `json:"repo"`
+	Build *drone.Build `json:"build"`
+	Name  string       `json:"name"`
+}
+
+// response is the payload the plugin endpoint is expected to return.
+type response struct {
+	Data               string `json:"data"`
+	PullRequestAllowed bool   `json:"pull_request_allowed"`
+}
+
+// Provider resolves secrets from a single configured HTTP plugin.
+type Provider struct {
+	config Config
+	client *http.Client
+}
+
+// New returns a secret.Provider backed by the HTTP plugin described by
+// config. A nil or zero-value Endpoint yields a provider that never
+// resolves anything, so callers can use it as a no-op default.
+func New(config Config) secret.Provider {
+	if config.Timeout <= 0 {
+		config.Timeout = 5 * time.Second
+	}
+	return &Provider{
+		config: config,
+		client: &http.Client{
+			Timeout: config.Timeout,
+			Transport: &http.Transport{
+				TLSClientConfig: &tls.Config{InsecureSkipVerify: config.SkipVerify}, //nolint:gosec // operator-controlled, optional
+			},
+		},
+	}
+}
+
+// Find resolves a single secret by name. Any failure to reach the plugin,
+// or a response indicating the build is a pull request that isn't
+// allowed to see the secret, degrades gracefully to a nil secret rather
+// than aborting compilation.
+func (p *Provider) Find(ctx context.Context, args *secret.Request) (*drone.Secret, error) {
+	if p.config.Endpoint == "" {
+		return nil, nil //nolint:nilnil // no plugin configured, nothing to resolve
+	}
+
+	req := &request{Repo: args.Repo, Build: args.Build, Name: args.Name}
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, nil //nolint:nilnil
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, p.config.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		logrus.WithError(err).Warnln("secret plugin: failed to build request")
+		return nil, nil //nolint:nilnil
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Authorization", "hmac "+sign(body, p.config.HMACKey))
+
+	resp, err := p.client.Do(httpReq)
+	if err != nil {
+		logrus.WithError(err).WithField("name", args.Name).Warnln("secret plugin: request failed")
+		return nil, nil //nolint:nilnil
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil //nolint:nilnil
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		logrus.WithField("name", args.Name).WithField("status", resp.StatusCode).
+			Warnln("secret plugin: endpoint returned an error")
+		return nil, nil //nolint:nilnil
+	}
+
+	out := new(response)
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		logrus.WithError(err).WithField("name", args.Name).Warnln("secret plugin: failed to decode response")
+		return nil, nil //nolint:nilnil
+	}
+
+	if args.Build != nil && args.Build.Event == drone.EventPullRequest && !out.PullRequestAllowed {
+		logrus.WithField("name", args.Name).Infoln("secret plugin: secret not allowed for pull request builds")
+		return nil, nil //nolint:nilnil
+	}
+
+	return &drone.Secret{
+		Name:        args.Name,
+		Data:        out.Data,
+		PullRequest: out.PullRequestAllowed,
+	}, nil
+}
+
+// sign computes the HMAC-SHA256 signature of body using key, matching the
+// signing scheme expected by the Drone secret extension protocol.
+func sign(body []byte, key string) string {
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
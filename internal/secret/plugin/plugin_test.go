@@ -0,0 +1,82 @@
+// Copyright 2020 Drone.IO Inc. All rights reserved.
+// Use of this source code is governed by the Polyform License
+// that can be found in the LICENSE file.
+
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/drone/drone-go/drone"
+	"github.com/drone/runner-go/secret"
+)
+
+func TestFindResolvesSecret(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") == "" {
+			t.Errorf("expected Authorization header to be set")
+		}
+		json.NewEncoder(w).Encode(response{Data: "s3cr3t", PullRequestAllowed: false}) //nolint:errcheck
+	}))
+	defer srv.Close()
+
+	p := New(Config{Endpoint: srv.URL, HMACKey: "topsecret"})
+	got, err := p.Find(context.Background(), &secret.Request{
+		Name:  "token",
+		Repo:  &drone.Repo{Slug: "octocat/hello-world"},
+		Build: &drone.Build{Event: drone.EventPush},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if got == nil || got.Data != "s3cr3t" {
+		t.Fatalf("want resolved secret, got %+v", got)
+	}
+}
+
+func TestFindDeniesUnallowedPullRequest(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(response{Data: "s3cr3t", PullRequestAllowed: false}) //nolint:errcheck
+	}))
+	defer srv.Close()
+
+	p := New(Config{Endpoint: srv.URL, HMACKey: "topsecret"})
+	got, err := p.Find(context.Background(), &secret.Request{
+		Name:  "token",
+		Build: &drone.Build{Event: drone.EventPullRequest},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if got != nil {
+		t.Fatalf("want secret withheld from pull request build, got %+v", got)
+	}
+}
+
+func TestFindDegradesGracefullyOnError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	p := New(Config{Endpoint: srv.URL, HMACKey: "topsecret"})
+	got, err := p.Find(context.Background(), &secret.Request{Name: "token"})
+	if err != nil {
+		t.Fatalf("expected no error, want graceful degradation, got %s", err)
+	}
+	if got != nil {
+		t.Fatalf("want nil secret on endpoint error, got %+v", got)
+	}
+}
+
+func TestFindNoopWithoutEndpoint(t *testing.T) {
+	p := New(Config{})
+	got, err := p.Find(context.Background(), &secret.Request{Name: "token"})
+	if err != nil || got != nil {
+		t.Fatalf("want no-op provider to return nil, nil, got %+v, %s", got, err)
+	}
+}
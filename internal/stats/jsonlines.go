@@ -0,0 +1,43 @@
+// Copyright 2020 Drone.IO Inc. All rights reserved.
+// Use of this source code is governed by the Polyform License
+// that can be found in the LICENSE file.
+
+package stats
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// JSONLinesSink appends one JSON object per record to a file, for
+// operators who want to ship the raw stats into a log pipeline (e.g.
+// Fluent Bit, Vector) rather than a metrics backend.
+type JSONLinesSink struct {
+	mu   sync.Mutex
+	file *os.File
+	enc  *json.Encoder
+}
+
+// NewJSONLinesSink opens (creating if necessary) the file at path for
+// appending.
+func NewJSONLinesSink(path string) (*JSONLinesSink, error) {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644) //nolint:gomnd
+	if err != nil {
+		return nil, errors.Wrap(err, "stats: failed to open jsonlines file")
+	}
+	return &JSONLinesSink{file: f, enc: json.NewEncoder(f)}, nil
+}
+
+func (s *JSONLinesSink) Record(_ context.Context, rec Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.enc.Encode(rec)
+}
+
+func (s *JSONLinesSink) Close() error {
+	return s.file.Close()
+}
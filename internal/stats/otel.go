@@ -0,0 +1,69 @@
+// Copyright 2020 Drone.IO Inc. All rights reserved.
+// Use of this source code is governed by the Polyform License
+// that can be found in the LICENSE file.
+
+package stats
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// OTelSink emits OS stats as OpenTelemetry histograms (max/avg CPU and
+// memory percentages, total memory and instance lifetime), labelled by
+// pool, OS, arch and provider, for operators who already ship telemetry
+// to an OTLP collector rather than scraping Prometheus. CPUCores and
+// StageRuntimeID are not recorded.
+type OTelSink struct {
+	maxCPU   metric.Float64Histogram
+	maxMem   metric.Float64Histogram
+	avgCPU   metric.Float64Histogram
+	avgMem   metric.Float64Histogram
+	totalMem metric.Float64Histogram
+	lifetime metric.Float64Histogram
+}
+
+// NewOTelSink creates the instruments used to record OS stats on the
+// given meter. Callers are expected to have already configured an OTLP
+// exporter on the meter provider the meter was obtained from.
+func NewOTelSink(meter metric.Meter) (*OTelSink, error) {
+	var err error
+	s := &OTelSink{}
+	if s.maxCPU, err = meter.Float64Histogram("drone.vm.cpu.max_pct"); err != nil {
+		return nil, err
+	}
+	if s.maxMem, err = meter.Float64Histogram("drone.vm.mem.max_pct"); err != nil {
+		return nil, err
+	}
+	if s.avgCPU, err = meter.Float64Histogram("drone.vm.cpu.avg_pct"); err != nil {
+		return nil, err
+	}
+	if s.avgMem, err = meter.Float64Histogram("drone.vm.mem.avg_pct"); err != nil {
+		return nil, err
+	}
+	if s.totalMem, err = meter.Float64Histogram("drone.vm.mem.total_mb"); err != nil {
+		return nil, err
+	}
+	if s.lifetime, err = meter.Float64Histogram("drone.vm.lifetime_seconds"); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *OTelSink) Record(ctx context.Context, rec Record) error {
+	attrs := metric.WithAttributes(
+		attribute.String("pool", rec.PoolID),
+		attribute.String("os", rec.OS),
+		attribute.String("arch", rec.Arch),
+		attribute.String("provider", rec.Provider),
+	)
+	s.maxCPU.Record(ctx, rec.MaxCPUUsagePct, attrs)
+	s.maxMem.Record(ctx, rec.MaxMemUsagePct, attrs)
+	s.avgCPU.Record(ctx, rec.AvgCPUUsagePct, attrs)
+	s.avgMem.Record(ctx, rec.AvgMemUsagePct, attrs)
+	s.totalMem.Record(ctx, rec.TotalMemMB, attrs)
+	s.lifetime.Record(ctx, rec.InstanceLifetime.Seconds(), attrs)
+	return nil
+}
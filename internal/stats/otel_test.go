@@ -0,0 +1,70 @@
+// Copyright 2020 Drone.IO Inc. All rights reserved.
+// Use of this source code is governed by the Polyform License
+// that can be found in the LICENSE file.
+
+package stats
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+func TestOTelSinkRecordsAllHistograms(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	provider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+
+	sink, err := NewOTelSink(provider.Meter("drone-runner-aws-test"))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	rec := Record{
+		PoolID:           "pool-1",
+		OS:               "linux",
+		Arch:             "amd64",
+		Provider:         "aws",
+		MaxCPUUsagePct:   91.5,
+		MaxMemUsagePct:   82.0,
+		AvgCPUUsagePct:   40.0,
+		AvgMemUsagePct:   30.0,
+		TotalMemMB:       2048,
+		InstanceLifetime: 90 * time.Second,
+	}
+	if err := sink.Record(context.Background(), rec); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	var data metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &data); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	got := map[string]float64{}
+	for _, sm := range data.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			hist, ok := m.Data.(metricdata.Histogram[float64])
+			if !ok || len(hist.DataPoints) == 0 {
+				continue
+			}
+			got[m.Name] = hist.DataPoints[0].Sum
+		}
+	}
+
+	want := map[string]float64{
+		"drone.vm.cpu.max_pct":      91.5,
+		"drone.vm.mem.max_pct":      82.0,
+		"drone.vm.cpu.avg_pct":      40.0,
+		"drone.vm.mem.avg_pct":      30.0,
+		"drone.vm.mem.total_mb":     2048,
+		"drone.vm.lifetime_seconds": 90.0,
+	}
+	for name, wantVal := range want {
+		if got[name] != wantVal {
+			t.Errorf("metric %s: want %v, got %v", name, wantVal, got[name])
+		}
+	}
+}
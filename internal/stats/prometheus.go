@@ -0,0 +1,24 @@
+// Copyright 2020 Drone.IO Inc. All rights reserved.
+// Use of this source code is governed by the Polyform License
+// that can be found in the LICENSE file.
+
+package stats
+
+import (
+	"context"
+
+	"github.com/drone-runners/drone-runner-aws/metric"
+)
+
+// PrometheusSink records the CPU/memory percentile histograms the runner
+// has always exposed. It is the default sink and preserves the exact
+// behavior the destroy path had before StatsSink existed.
+type PrometheusSink struct {
+	Metrics *metric.Metrics
+}
+
+func (s PrometheusSink) Record(_ context.Context, rec Record) error {
+	s.Metrics.CPUPercentile.WithLabelValues(rec.PoolID, rec.OS, rec.Arch, rec.Provider).Observe(rec.MaxCPUUsagePct)
+	s.Metrics.MemoryPercentile.WithLabelValues(rec.PoolID, rec.OS, rec.Arch, rec.Provider).Observe(rec.MaxMemUsagePct)
+	return nil
+}
@@ -0,0 +1,57 @@
+// Copyright 2020 Drone.IO Inc. All rights reserved.
+// Use of this source code is governed by the Polyform License
+// that can be found in the LICENSE file.
+
+// Package stats fans out the lite-engine OS stats collected on VM
+// destroy to one or more structured telemetry backends, beyond the
+// coarse Prometheus percentile buckets the runner has always recorded.
+package stats
+
+import (
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Record is a structured snapshot of a destroyed VM's lite-engine OS
+// stats, enriched with the labels needed to slice it in a dashboard.
+type Record struct {
+	PoolID         string
+	StageRuntimeID string
+	Provider       string
+	OS             string
+	Arch           string
+
+	TotalMemMB     float64
+	CPUCores       int64
+	AvgMemUsagePct float64
+	AvgCPUUsagePct float64
+	MaxMemUsagePct float64
+	MaxCPUUsagePct float64
+
+	// InstanceLifetime is how long the instance existed for, from
+	// creation to this destroy call.
+	InstanceLifetime time.Duration
+}
+
+// Sink receives one Record per destroyed VM. Implementations should not
+// block the destroy path for long; a slow or unreachable backend should
+// log and drop rather than propagate an error that aborts destroy.
+type Sink interface {
+	Record(ctx context.Context, rec Record) error
+}
+
+// Fanout broadcasts a Record to every configured Sink, logging but
+// swallowing individual sink failures so one bad backend can't affect
+// the others or the destroy path itself.
+type Fanout []Sink
+
+func (f Fanout) Record(ctx context.Context, rec Record) error {
+	for _, sink := range f {
+		if err := sink.Record(ctx, rec); err != nil {
+			logrus.WithError(err).WithField("sink", sink).Warnln("stats: sink failed to record")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+// Copyright 2020 Drone.IO Inc. All rights reserved.
+// Use of this source code is governed by the Polyform License
+// that can be found in the LICENSE file.
+
+package stats
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type fakeSink struct {
+	records []Record
+}
+
+func (f *fakeSink) Record(_ context.Context, rec Record) error {
+	f.records = append(f.records, rec)
+	return nil
+}
+
+func TestFanoutRecordsToEverySink(t *testing.T) {
+	a, b := &fakeSink{}, &fakeSink{}
+	fanout := Fanout{a, b}
+
+	rec := Record{PoolID: "pool-1", MaxCPUUsagePct: 91.5}
+	if err := fanout.Record(context.Background(), rec); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	for _, sink := range []*fakeSink{a, b} {
+		if len(sink.records) != 1 || sink.records[0].PoolID != "pool-1" {
+			t.Errorf("want record forwarded to every sink, got %+v", sink.records)
+		}
+	}
+}
+
+func TestJSONLinesSinkAppendsOneRecordPerLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "stats.jsonl")
+	sink, err := NewJSONLinesSink(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer sink.Close()
+
+	want := Record{PoolID: "pool-1", StageRuntimeID: "stage-1", MaxCPUUsagePct: 42}
+	if err := sink.Record(context.Background(), want); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if err := sink.Record(context.Background(), want); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	lines := 0
+	for scanner.Scan() {
+		var got Record
+		if err := json.Unmarshal(scanner.Bytes(), &got); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if got.StageRuntimeID != "stage-1" {
+			t.Errorf("want stage-1, got %s", got.StageRuntimeID)
+		}
+		lines++
+	}
+	if lines != 2 {
+		t.Errorf("want 2 lines written, got %d", lines)
+	}
+}
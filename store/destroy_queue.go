@@ -0,0 +1,32 @@
+// Copyright 2020 Drone.IO Inc. All rights reserved.
+// Use of this source code is governed by the Polyform License
+// that can be found in the LICENSE file.
+
+package store
+
+import "context"
+
+// QueuedDestroyRequest is a VM destroy request that has been buffered
+// because the pool manager is paused. It mirrors harness.VMCleanupRequest
+// but lives in the store package, since harness already imports store and
+// a reverse import would cycle; Context is carried as raw JSON and
+// re-decoded by the caller that dequeues it.
+type QueuedDestroyRequest struct {
+	PoolID         string `json:"pool_id"`
+	StageRuntimeID string `json:"stage_runtime_id"`
+	LogKey         string `json:"log_key,omitempty"`
+	Context        []byte `json:"context,omitempty"`
+}
+
+// DestroyQueueStore persists destroy requests received while the pool
+// manager is paused, so they are not lost racing against in-flight
+// initializes and can be drained in FIFO order once resumed.
+type DestroyQueueStore interface {
+	// Enqueue appends a request to the back of the queue.
+	Enqueue(ctx context.Context, req *QueuedDestroyRequest) error
+	// Dequeue removes and returns the oldest queued request, or nil if
+	// the queue is empty.
+	Dequeue(ctx context.Context) (*QueuedDestroyRequest, error)
+	// Len returns the number of requests currently queued.
+	Len(ctx context.Context) (int, error)
+}
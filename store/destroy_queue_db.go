@@ -0,0 +1,89 @@
+// Copyright 2020 Drone.IO Inc. All rights reserved.
+// Use of this source code is governed by the Polyform License
+// that can be found in the LICENSE file.
+
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// dbDestroyQueueStore is a DestroyQueueStore backed by the runner's own
+// database (the same one StageOwnerStore uses), so requests buffered
+// while the pool manager is paused survive a runner restart or upgrade
+// instead of living only in process memory.
+type dbDestroyQueueStore struct {
+	db *sql.DB
+}
+
+// NewDBDestroyQueueStore returns a DestroyQueueStore backed by db,
+// creating the destroy_queue table if it does not already exist.
+func NewDBDestroyQueueStore(db *sql.DB) (DestroyQueueStore, error) {
+	const schema = `
+CREATE TABLE IF NOT EXISTS destroy_queue (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	pool_id TEXT,
+	stage_runtime_id TEXT NOT NULL,
+	log_key TEXT,
+	context BLOB,
+	created_at INTEGER NOT NULL
+)`
+	if _, err := db.Exec(schema); err != nil {
+		return nil, fmt.Errorf("failed to create destroy_queue table: %w", err)
+	}
+	return &dbDestroyQueueStore{db: db}, nil
+}
+
+func (s *dbDestroyQueueStore) Enqueue(ctx context.Context, req *QueuedDestroyRequest) error {
+	_, err := s.db.ExecContext(ctx,
+		`INSERT INTO destroy_queue (pool_id, stage_runtime_id, log_key, context, created_at) VALUES (?, ?, ?, ?, strftime('%s','now'))`,
+		req.PoolID, req.StageRuntimeID, req.LogKey, req.Context,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to enqueue destroy request: %w", err)
+	}
+	return nil
+}
+
+// Dequeue removes and returns the oldest queued request inside a
+// transaction, so two runner instances draining the same queue can't
+// both pop the same row.
+func (s *dbDestroyQueueStore) Dequeue(ctx context.Context) (*QueuedDestroyRequest, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	var (
+		id  int64
+		req QueuedDestroyRequest
+	)
+	row := tx.QueryRowContext(ctx,
+		`SELECT id, pool_id, stage_runtime_id, log_key, context FROM destroy_queue ORDER BY id ASC LIMIT 1`)
+	if err := row.Scan(&id, &req.PoolID, &req.StageRuntimeID, &req.LogKey, &req.Context); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read oldest queued destroy request: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM destroy_queue WHERE id = ?`, id); err != nil {
+		return nil, fmt.Errorf("failed to remove dequeued destroy request: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit dequeue transaction: %w", err)
+	}
+	return &req, nil
+}
+
+func (s *dbDestroyQueueStore) Len(ctx context.Context) (int, error) {
+	var n int
+	if err := s.db.QueryRowContext(ctx, `SELECT COUNT(1) FROM destroy_queue`).Scan(&n); err != nil {
+		return 0, fmt.Errorf("failed to count queued destroy requests: %w", err)
+	}
+	return n, nil
+}
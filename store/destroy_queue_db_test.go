@@ -0,0 +1,87 @@
+// Copyright 2020 Drone.IO Inc. All rights reserved.
+// Use of this source code is governed by the Polyform License
+// that can be found in the LICENSE file.
+
+package store
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func openTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestDBDestroyQueueStoreSurvivesReopen(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t)
+
+	q, err := NewDBDestroyQueueStore(db)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if err := q.Enqueue(ctx, &QueuedDestroyRequest{StageRuntimeID: "stage-1"}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// Re-wrap the same *sql.DB in a fresh store value, simulating a
+	// runner restart that reconnects to the same database file.
+	q2, err := NewDBDestroyQueueStore(db)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	n, err := q2.Len(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if n != 1 {
+		t.Fatalf("want queued request to survive reconnect, got len %d", n)
+	}
+
+	item, err := q2.Dequeue(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if item == nil || item.StageRuntimeID != "stage-1" {
+		t.Errorf("want dequeued stage-1, got %+v", item)
+	}
+}
+
+func TestDBDestroyQueueStoreFIFOOrder(t *testing.T) {
+	ctx := context.Background()
+	q, err := NewDBDestroyQueueStore(openTestDB(t))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	for _, id := range []string{"stage-1", "stage-2", "stage-3"} {
+		if err := q.Enqueue(ctx, &QueuedDestroyRequest{StageRuntimeID: id}); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+
+	for _, want := range []string{"stage-1", "stage-2", "stage-3"} {
+		item, err := q.Dequeue(ctx)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if item == nil || item.StageRuntimeID != want {
+			t.Errorf("want %s, got %+v", want, item)
+		}
+	}
+
+	if item, err := q.Dequeue(ctx); err != nil || item != nil {
+		t.Errorf("want empty queue, got %+v, err=%s", item, err)
+	}
+}
@@ -0,0 +1,49 @@
+// Copyright 2020 Drone.IO Inc. All rights reserved.
+// Use of this source code is governed by the Polyform License
+// that can be found in the LICENSE file.
+
+package store
+
+import (
+	"context"
+	"sync"
+)
+
+// memoryDestroyQueueStore is a DestroyQueueStore backed by an in-process
+// slice. It exists for tests and for callers that run without a
+// database configured; production use should prefer
+// NewDBDestroyQueueStore, which survives a runner restart.
+type memoryDestroyQueueStore struct {
+	mu    sync.Mutex
+	items []*QueuedDestroyRequest
+}
+
+// NewMemoryDestroyQueueStore returns a DestroyQueueStore that buffers
+// requests in memory.
+func NewMemoryDestroyQueueStore() DestroyQueueStore {
+	return &memoryDestroyQueueStore{}
+}
+
+func (s *memoryDestroyQueueStore) Enqueue(_ context.Context, req *QueuedDestroyRequest) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.items = append(s.items, req)
+	return nil
+}
+
+func (s *memoryDestroyQueueStore) Dequeue(_ context.Context) (*QueuedDestroyRequest, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.items) == 0 {
+		return nil, nil
+	}
+	item := s.items[0]
+	s.items = s.items[1:]
+	return item, nil
+}
+
+func (s *memoryDestroyQueueStore) Len(_ context.Context) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.items), nil
+}